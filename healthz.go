@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// healthStatus is the tri-state result of an OS service health check,
+// modeled after Consul's agent/checks os_service check (Passing/Warning/Critical).
+type healthStatus string
+
+const (
+	healthPassing  healthStatus = "passing"
+	healthWarning  healthStatus = "warning"
+	healthCritical healthStatus = "critical"
+)
+
+// Sentinel errors returned by OSServiceClient.Check so callers can branch on
+// *why* a service isn't healthy instead of string-matching error text.
+var (
+	ErrOSServiceStatusCritical = errors.New("service is in a critical state")
+	ErrOSServiceNotFound       = errors.New("service not found")
+)
+
+// OSServiceClient checks the liveness of a Windows service through the SCM,
+// the same way Consul's agent/checks os_service check does for its
+// health-check subsystem.
+type OSServiceClient struct{}
+
+// Check connects to the SCM, queries name, and maps its current state to a
+// healthStatus plus an error describing non-passing results. Stopped/Paused
+// services and missing services yield the sentinel errors above; pending
+// states and SCM connect/open/query failures yield a plain error.
+func (OSServiceClient) Check(name string) (healthStatus, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return healthCritical, fmt.Errorf("could not connect to service manager: %v", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+			return healthCritical, ErrOSServiceNotFound
+		}
+		return healthCritical, fmt.Errorf("could not access service: %v", err)
+	}
+	defer s.Close()
+
+	status, err := s.Query()
+	if err != nil {
+		return healthCritical, fmt.Errorf("could not query service: %v", err)
+	}
+
+	switch status.State {
+	case svc.Running:
+		return healthPassing, nil
+	case svc.Stopped, svc.Paused:
+		return healthCritical, ErrOSServiceStatusCritical
+	case svc.StartPending, svc.StopPending, svc.ContinuePending, svc.PausePending:
+		return healthWarning, fmt.Errorf("service is in pending state %v", status.State)
+	default:
+		return healthWarning, fmt.Errorf("service is in unexpected state %v", status.State)
+	}
+}
+
+// healthzResult is the JSON shape returned by /healthz and /healthz/batch.
+type healthzResult struct {
+	Service string       `json:"service"`
+	Status  healthStatus `json:"status"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// statusHTTPCode maps a healthStatus onto the HTTP status Prometheus'
+// blackbox exporter and consul-style scrapers expect from a liveness probe.
+func statusHTTPCode(status healthStatus) int {
+	switch status {
+	case healthPassing:
+		return http.StatusOK
+	case healthWarning:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusServiceUnavailable
+	}
+}
+
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	mySvcName := getServiceName(r)
+
+	status, err := (OSServiceClient{}).Check(mySvcName)
+	result := healthzResult{Service: mySvcName, Status: status}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	writeJSON(w, statusHTTPCode(status), result)
+}
+
+func handleHealthzBatch(w http.ResponseWriter, r *http.Request) {
+	var names []string
+	if err := json.NewDecoder(r.Body).Decode(&names); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	client := OSServiceClient{}
+	results := make([]healthzResult, 0, len(names))
+	worst := healthPassing
+	for _, name := range names {
+		status, err := client.Check(name)
+		result := healthzResult{Service: name, Status: status}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+		if worseStatus(status, worst) {
+			worst = status
+		}
+	}
+
+	writeJSON(w, statusHTTPCode(worst), results)
+}
+
+// worseStatus reports whether a ranks worse than b on the
+// passing < warning < critical scale.
+func worseStatus(a, b healthStatus) bool {
+	rank := map[healthStatus]int{healthPassing: 0, healthWarning: 1, healthCritical: 2}
+	return rank[a] > rank[b]
+}
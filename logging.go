@@ -0,0 +1,35 @@
+package main
+
+import (
+	"golang.org/x/sys/windows/svc/debug"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// Event IDs recorded to the Windows Event Log so admins can filter Event
+// Viewer by what kind of thing happened instead of parsing message text.
+const (
+	eventIDStart           = 1
+	eventIDStop            = 2
+	eventIDHTTPError       = 3
+	eventIDSvcControlError = 4
+	eventIDSessionChange   = 5
+)
+
+// logger is the process-wide sink for operational messages. It defaults to
+// a console logger so running interactively (or under `debug`) just prints
+// to stdout; initLogger upgrades it to the real Windows Event Log once we
+// know we're running as an installed service.
+var logger debug.Log = debug.New(serviceName)
+
+// initLogger switches logger to eventlog.Open(serviceName), the event source
+// that installService registers via eventlog.InstallAsEventCreate. If Open
+// fails we keep the console logger so a logging problem doesn't take down the
+// service itself.
+func initLogger() {
+	elog, err := eventlog.Open(serviceName)
+	if err != nil {
+		logger.Warning(eventIDSvcControlError, "could not open event log, falling back to console logging: "+err.Error())
+		return
+	}
+	logger = elog
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"golang.org/x/sys/windows/svc"
@@ -17,10 +20,17 @@ const (
 )
 
 func main() {
+	// 0. Handle install/remove/debug/start/stop/pause/continue CLI verbs so
+	// ops can manage this service without external sc.exe gymnastics.
+	if runCLI(os.Args[1:]) {
+		return
+	}
+
 	// 1. Determine if we are running as a Service or a Console
 	isService, err := svc.IsWindowsService()
 	if err != nil {
-		log.Fatalf("failed to determine if we are running in an interactive session: %v", err)
+		logger.Error(eventIDSvcControlError, fmt.Sprintf("failed to determine if we are running in an interactive session: %v", err))
+		os.Exit(1)
 	}
 
 	if isService {
@@ -38,21 +48,25 @@ func main() {
 type myService struct{}
 
 func runService(name string) {
+	initLogger()
+
 	err := svc.Run(name, &myService{})
 	if err != nil {
-		log.Fatalf("%s service failed: %v", name, err)
+		logger.Error(eventIDSvcControlError, fmt.Sprintf("%s service failed: %v", name, err))
+		os.Exit(1)
 	}
 }
 
 // Execute is called by Windows Service Manager
 func (m *myService) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (ssec bool, errno uint32) {
-	const cmdsAccepted = svc.AcceptStop | svc.AcceptShutdown
+	const cmdsAccepted = svc.AcceptStop | svc.AcceptShutdown | svc.AcceptPauseAndContinue | svc.AcceptSessionChange
 
 	// Tell Windows we are "Running"
 	changes <- svc.Status{State: svc.Running, Accepts: cmdsAccepted}
 
 	// Start the HTTP Server in a separate Goroutine
-	go startServer()
+	srv := newServer()
+	go runServer(srv)
 
 	// Wait for a Stop signal from Windows
 loop:
@@ -64,29 +78,76 @@ loop:
 				changes <- c.CurrentStatus
 			case svc.Stop, svc.Shutdown:
 				break loop
+			case svc.Pause:
+				changes <- svc.Status{State: svc.PausePending}
+				shutdownServer(srv)
+				changes <- svc.Status{State: svc.Paused, Accepts: cmdsAccepted}
+			case svc.Continue:
+				changes <- svc.Status{State: svc.ContinuePending}
+				srv = newServer()
+				go runServer(srv)
+				changes <- svc.Status{State: svc.Running, Accepts: cmdsAccepted}
+			case svc.SessionChange:
+				logSessionChange(c)
 			default:
-				log.Printf("unexpected control request #%d", c)
+				logger.Warning(eventIDSvcControlError, fmt.Sprintf("unexpected control request #%d", c.Cmd))
 			}
 		}
 	}
 
 	changes <- svc.Status{State: svc.StopPending}
+	shutdownServer(srv)
 	return
 }
 
 // --- The Actual Web Server Logic ---
 
-func startServer() {
-	http.HandleFunc("/start", handleStart)
-	http.HandleFunc("/stop", handleStop)
-	http.HandleFunc("/status", handleStatus)
+// newMux wires up the handler routes on a fresh ServeMux so that pausing and
+// continuing the service can rebuild the *http.Server without re-registering
+// routes on (and panicking on) the global DefaultServeMux.
+func newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/start", handleStart)
+	mux.HandleFunc("/stop", handleStop)
+	mux.HandleFunc("/status", handleStatus)
+	mux.HandleFunc("/healthz", handleHealthz)
+	mux.HandleFunc("/healthz/batch", handleHealthzBatch)
+	mux.HandleFunc("/config", handleConfig)
+	mux.HandleFunc("/recovery", handleRecovery)
+	mux.HandleFunc("/services", handleServices)
+	return mux
+}
+
+func newServer() *http.Server {
+	return &http.Server{
+		Addr:    serverPort,
+		Handler: newMux(),
+	}
+}
+
+func runServer(srv *http.Server) {
+	logger.Info(eventIDStart, fmt.Sprintf("Service Handler listening on %s", srv.Addr))
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Error(eventIDHTTPError, err.Error())
+		os.Exit(1)
+	}
+}
 
-	log.Printf("Service Handler listening on %s", serverPort)
-	if err := http.ListenAndServe(serverPort, nil); err != nil {
-		log.Fatal(err)
+// shutdownServer closes the listener and then gives in-flight requests up to
+// 5 seconds to finish, giving Pause/Stop a clean handoff instead of dropping
+// connections.
+func shutdownServer(srv *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Warning(eventIDStop, fmt.Sprintf("error shutting down http server: %v", err))
 	}
 }
 
+func startServer() {
+	runServer(newServer())
+}
+
 // --- Endpoint Handlers ---
 
 // Helper to get service name from Query Param or Default
@@ -98,29 +159,61 @@ func getServiceName(r *http.Request) string {
 	return mySvcName
 }
 
+// getTimeout reads the ?timeout= query param (e.g. "30s") a caller can use to
+// override defaultWaitTimeout, falling back to the default on an empty or
+// unparsable value.
+func getTimeout(r *http.Request) time.Duration {
+	if v := r.URL.Query().Get("timeout"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return defaultWaitTimeout
+}
+
 func handleStart(w http.ResponseWriter, r *http.Request) {
 	mySvcName := getServiceName(r)
-	
-	if err := startWindowsService(mySvcName); err != nil {
+	timeout := getTimeout(r)
+
+	err := startWindowsService(mySvcName, timeout)
+	switch {
+	case err == nil:
+		fmt.Fprintf(w, "Service '%s' started successfully.\n", mySvcName)
+	case errors.Is(err, ErrAlreadyRunning):
+		fmt.Fprintf(w, "Service '%s' is already running.\n", mySvcName)
+	default:
 		http.Error(w, fmt.Sprintf("Failed to start service '%s': %v", mySvcName, err), http.StatusInternalServerError)
-		return
 	}
-	fmt.Fprintf(w, "Service '%s' started successfully.\n", mySvcName)
 }
 
 func handleStop(w http.ResponseWriter, r *http.Request) {
 	mySvcName := getServiceName(r)
-
-	if err := stopWindowsService(mySvcName); err != nil {
+	timeout := getTimeout(r)
+
+	err := stopWindowsService(mySvcName, timeout)
+	switch {
+	case err == nil:
+		fmt.Fprintf(w, "Service '%s' stopped successfully.\n", mySvcName)
+	case errors.Is(err, ErrAlreadyStopped):
+		fmt.Fprintf(w, "Service '%s' is already stopped.\n", mySvcName)
+	default:
 		http.Error(w, fmt.Sprintf("Failed to stop service '%s': %v", mySvcName, err), http.StatusInternalServerError)
-		return
 	}
-	fmt.Fprintf(w, "Service '%s' stopped successfully.\n", mySvcName)
 }
 
 func handleStatus(w http.ResponseWriter, r *http.Request) {
 	mySvcName := getServiceName(r)
 
+	if wantsJSON(r) {
+		detail, err := getServiceStatusDetail(mySvcName)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get status for '%s': %v", mySvcName, err), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, detail)
+		return
+	}
+
 	status, err := getServiceStatus(mySvcName)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to get status for '%s': %v", mySvcName, err), http.StatusInternalServerError)
@@ -129,9 +222,29 @@ func handleStatus(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "Service '%s' state: %s\n", mySvcName, status)
 }
 
+// wantsJSON reports whether the caller asked for the structured response
+// via ?format=json or an Accept: application/json header.
+func wantsJSON(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "json" {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
 // --- Helper Functions ---
 
-func startWindowsService(name string) error {
+// Sentinel errors returned when the requested transition is a no-op because
+// the service already reports the desired state.
+var (
+	ErrAlreadyRunning = errors.New("service is already running")
+	ErrAlreadyStopped = errors.New("service is already stopped")
+)
+
+// defaultWaitTimeout is used by startWindowsService/stopWindowsService when
+// the caller doesn't override it via the ?timeout= query param.
+const defaultWaitTimeout = 10 * time.Second
+
+func startWindowsService(name string, timeout time.Duration) error {
 	m, err := mgr.Connect()
 	if err != nil {
 		return err
@@ -144,15 +257,25 @@ func startWindowsService(name string) error {
 	}
 	defer s.Close()
 
-	err = s.Start()
+	status, err := s.Query()
 	if err != nil {
-		return fmt.Errorf("could not start service: %v", err)
+		return fmt.Errorf("could not query service: %v", err)
+	}
+	if status.State == svc.Running {
+		return ErrAlreadyRunning
+	}
+
+	if err := s.Start(); err != nil {
+		// Propagate unchanged: callers may want to errors.As into a
+		// syscall.Errno to tell ERROR_SERVICE_ALREADY_RUNNING apart from
+		// other start failures.
+		return err
 	}
 
-	return waitForState(s, svc.Running)
+	return waitForState(s, svc.Running, timeout)
 }
 
-func stopWindowsService(name string) error {
+func stopWindowsService(name string, timeout time.Duration) error {
 	m, err := mgr.Connect()
 	if err != nil {
 		return err
@@ -165,29 +288,80 @@ func stopWindowsService(name string) error {
 	}
 	defer s.Close()
 
-	_, err = s.Control(svc.Stop)
+	status, err := s.Query()
 	if err != nil {
-		return fmt.Errorf("could not send stop control: %v", err)
+		return fmt.Errorf("could not query service: %v", err)
+	}
+	if status.State == svc.Stopped {
+		return ErrAlreadyStopped
+	}
+
+	if _, err := s.Control(svc.Stop); err != nil {
+		// Propagate unchanged: callers may want to errors.As into a
+		// syscall.Errno to tell ERROR_SERVICE_NOT_ACTIVE,
+		// ERROR_SERVICE_CANNOT_ACCEPT_CTRL, etc. apart.
+		return err
 	}
 
-	return waitForState(s, svc.Stopped)
+	return waitForState(s, svc.Stopped, timeout)
 }
 
-func waitForState(s *mgr.Service, desired svc.State) error {
-	timeout := time.Now().Add(10 * time.Second)
-	for {
-		status, err := s.Query()
+// maxStallPolls bounds how many consecutive polls waitForState will accept
+// a flat CheckPoint before giving up early. Services routinely sit on the
+// same CheckPoint for a poll or two while still genuinely transitioning, so
+// a single non-advancing poll must not be treated as a stall.
+const maxStallPolls = 3
+
+// waitForState polls s until it reaches desired or timeout elapses. Between
+// polls it sleeps for status.WaitHint/10, clamped to [1s, 10s] as Microsoft
+// recommends for SCM clients. The overall timeout is the primary bound; a
+// CheckPoint that fails to advance for maxStallPolls in a row fails fast
+// instead of waiting out the rest of the timeout on a service that's
+// genuinely wedged.
+func waitForState(s *mgr.Service, desired svc.State, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	status, err := s.Query()
+	if err != nil {
+		return err
+	}
+
+	stallPolls := 0
+	for status.State != desired {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for service to reach state %v (current: %v)", desired, status.State)
+		}
+
+		wait := time.Duration(status.WaitHint/10) * time.Millisecond
+		if wait < time.Second {
+			wait = time.Second
+		} else if wait > 10*time.Second {
+			wait = 10 * time.Second
+		}
+
+		prevCheckPoint := status.CheckPoint
+		time.Sleep(wait)
+
+		status, err = s.Query()
 		if err != nil {
 			return err
 		}
 		if status.State == desired {
-			return nil
+			break
+		}
+
+		if status.CheckPoint > prevCheckPoint {
+			stallPolls = 0
+			continue
 		}
-		if time.Now().After(timeout) {
-			return fmt.Errorf("timeout waiting for service state change")
+
+		stallPolls++
+		if stallPolls >= maxStallPolls {
+			return fmt.Errorf("service stalled in state %v: CheckPoint has not advanced across %d polls", status.State, stallPolls)
 		}
-		time.Sleep(300 * time.Millisecond)
 	}
+
+	return nil
 }
 
 func getServiceStatus(name string) (string, error) {
@@ -208,16 +382,28 @@ func getServiceStatus(name string) (string, error) {
 		return "", err
 	}
 
-	switch status.State {
+	return stateName(status.State), nil
+}
+
+// stateName renders a svc.State the way handleStatus's plain-text response
+// and statusDetail's JSON response both want it.
+func stateName(state svc.State) string {
+	switch state {
 	case svc.Stopped:
-		return "Stopped", nil
+		return "Stopped"
 	case svc.StartPending:
-		return "Start Pending", nil
+		return "Start Pending"
 	case svc.StopPending:
-		return "Stop Pending", nil
+		return "Stop Pending"
 	case svc.Running:
-		return "Running", nil
+		return "Running"
+	case svc.ContinuePending:
+		return "Continue Pending"
+	case svc.PausePending:
+		return "Pause Pending"
+	case svc.Paused:
+		return "Paused"
 	default:
-		return "Unknown", nil
+		return "Unknown"
 	}
 }
\ No newline at end of file
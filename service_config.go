@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// statusDetail is the JSON representation of GET /status?format=json,
+// exposing the full mgr.Config/RecoveryActions surface that the plain-text
+// response only summarizes as a state string.
+type statusDetail struct {
+	State                   string               `json:"state"`
+	ProcessId               uint32               `json:"process_id"`
+	Win32ExitCode           uint32               `json:"win32_exit_code"`
+	ServiceSpecificExitCode uint32               `json:"service_specific_exit_code"`
+	CheckPoint              uint32               `json:"check_point"`
+	WaitHint                uint32               `json:"wait_hint"`
+	Config                  configJSON           `json:"config"`
+	RecoveryActions         []recoveryActionJSON `json:"recovery_actions"`
+}
+
+// configJSON mirrors mgr.Config, dropping ServiceStartName/Password since
+// those are credentials rather than status to report back over HTTP. TagId
+// and SidType are not included either.
+type configJSON struct {
+	ServiceType      uint32   `json:"service_type"`
+	StartType        uint32   `json:"start_type"`
+	ErrorControl     uint32   `json:"error_control"`
+	BinaryPathName   string   `json:"binary_path_name"`
+	LoadOrderGroup   string   `json:"load_order_group,omitempty"`
+	Dependencies     []string `json:"dependencies,omitempty"`
+	DisplayName      string   `json:"display_name"`
+	Description      string   `json:"description"`
+	DelayedAutoStart bool     `json:"delayed_auto_start"`
+}
+
+func toConfigJSON(c mgr.Config) configJSON {
+	return configJSON{
+		ServiceType:      c.ServiceType,
+		StartType:        c.StartType,
+		ErrorControl:     c.ErrorControl,
+		BinaryPathName:   c.BinaryPathName,
+		LoadOrderGroup:   c.LoadOrderGroup,
+		Dependencies:     c.Dependencies,
+		DisplayName:      c.DisplayName,
+		Description:      c.Description,
+		DelayedAutoStart: c.DelayedAutoStart,
+	}
+}
+
+// recoveryActionJSON mirrors mgr.RecoveryAction, rendering Delay in
+// milliseconds since time.Duration doesn't round-trip through JSON.
+type recoveryActionJSON struct {
+	Type  uint32 `json:"type"`
+	Delay int64  `json:"delay_ms"`
+}
+
+func toRecoveryActionsJSON(actions []mgr.RecoveryAction) []recoveryActionJSON {
+	out := make([]recoveryActionJSON, len(actions))
+	for i, a := range actions {
+		out[i] = recoveryActionJSON{Type: uint32(a.Type), Delay: a.Delay.Milliseconds()}
+	}
+	return out
+}
+
+// getServiceStatusDetail gathers Query, Config and RecoveryActions into a
+// single statusDetail for the JSON /status response.
+func getServiceStatusDetail(name string) (statusDetail, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return statusDetail{}, err
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return statusDetail{}, fmt.Errorf("could not access service: %v", err)
+	}
+	defer s.Close()
+
+	status, err := s.Query()
+	if err != nil {
+		return statusDetail{}, fmt.Errorf("could not query service: %v", err)
+	}
+
+	cfg, err := s.Config()
+	if err != nil {
+		return statusDetail{}, fmt.Errorf("could not read config: %v", err)
+	}
+
+	actions, err := s.RecoveryActions()
+	if err != nil {
+		return statusDetail{}, fmt.Errorf("could not read recovery actions: %v", err)
+	}
+
+	return statusDetail{
+		State:                   stateName(status.State),
+		ProcessId:               status.ProcessId,
+		Win32ExitCode:           status.Win32ExitCode,
+		ServiceSpecificExitCode: status.ServiceSpecificExitCode,
+		CheckPoint:              status.CheckPoint,
+		WaitHint:                status.WaitHint,
+		Config:                  toConfigJSON(cfg),
+		RecoveryActions:         toRecoveryActionsJSON(actions),
+	}, nil
+}
+
+// configUpdateRequest is the PUT /config body. Pointer/nil fields are left
+// untouched so a caller can update just StartType without clobbering
+// Description or Dependencies.
+type configUpdateRequest struct {
+	StartType    *uint32  `json:"start_type,omitempty"`
+	Description  *string  `json:"description,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// handleConfig serves GET/PUT /config?service-name=..., reading or updating
+// StartType, Description and Dependencies via mgr.Service.UpdateConfig.
+func handleConfig(w http.ResponseWriter, r *http.Request) {
+	mySvcName := getServiceName(r)
+
+	m, err := mgr.Connect()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(mySvcName)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not access service '%s': %v", mySvcName, err), http.StatusInternalServerError)
+		return
+	}
+	defer s.Close()
+
+	cfg, err := s.Config()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not read config: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, toConfigJSON(cfg))
+
+	case http.MethodPut:
+		var update configUpdateRequest
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if update.StartType != nil {
+			cfg.StartType = *update.StartType
+		}
+		if update.Description != nil {
+			cfg.Description = *update.Description
+		}
+		if update.Dependencies != nil {
+			cfg.Dependencies = update.Dependencies
+		}
+
+		if err := s.UpdateConfig(cfg); err != nil {
+			http.Error(w, fmt.Sprintf("could not update config: %v", err), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, toConfigJSON(cfg))
+
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// recoveryUpdateRequest is the PUT /recovery body: the ordered list of
+// actions to take on consecutive failures, plus the reset period (seconds
+// of continuous good health after which the failure count resets) that
+// mgr.Service.SetRecoveryActions requires alongside it.
+type recoveryUpdateRequest struct {
+	Actions     []recoveryActionJSON `json:"actions"`
+	ResetPeriod uint32               `json:"reset_period_seconds"`
+}
+
+// handleRecovery serves GET/PUT /recovery?service-name=..., reading or
+// replacing the service's restart/reboot/run-command recovery actions.
+func handleRecovery(w http.ResponseWriter, r *http.Request) {
+	mySvcName := getServiceName(r)
+
+	m, err := mgr.Connect()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(mySvcName)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not access service '%s': %v", mySvcName, err), http.StatusInternalServerError)
+		return
+	}
+	defer s.Close()
+
+	switch r.Method {
+	case http.MethodGet:
+		actions, err := s.RecoveryActions()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("could not read recovery actions: %v", err), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, toRecoveryActionsJSON(actions))
+
+	case http.MethodPut:
+		var update recoveryUpdateRequest
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		actions := make([]mgr.RecoveryAction, len(update.Actions))
+		for i, a := range update.Actions {
+			actions[i] = mgr.RecoveryAction{
+				Type:  int(a.Type),
+				Delay: time.Duration(a.Delay) * time.Millisecond,
+			}
+		}
+
+		if err := s.SetRecoveryActions(actions, update.ResetPeriod); err != nil {
+			http.Error(w, fmt.Sprintf("could not set recovery actions: %v", err), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, update.Actions)
+
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
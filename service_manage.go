@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/debug"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// svcDisplayName and svcDescription are what install registers with the SCM
+// and what operators see in services.msc once the handler is installed.
+const (
+	svcDisplayName = "Go Windows Service Handler"
+	svcDescription = "HTTP API for starting, stopping and inspecting Windows services."
+)
+
+// runCLI handles the self-management verbs (install, remove, start, stop,
+// pause, continue, debug) so the binary can register and control itself as
+// a Windows service without any external sc.exe invocation. It reports
+// whether args contained a recognized verb; when false, main falls through
+// to the normal service/console startup path.
+func runCLI(args []string) bool {
+	if len(args) < 1 {
+		return false
+	}
+
+	cmd := strings.ToLower(args[0])
+	var err error
+
+	switch cmd {
+	case "install":
+		err = installService(serviceName, svcDisplayName, svcDescription)
+	case "remove":
+		err = removeService(serviceName)
+	case "start":
+		err = startWindowsService(serviceName, defaultWaitTimeout)
+	case "stop":
+		err = stopWindowsService(serviceName, defaultWaitTimeout)
+	case "pause":
+		err = controlService(serviceName, svc.Pause, svc.Paused)
+	case "continue":
+		err = controlService(serviceName, svc.Continue, svc.Running)
+	case "debug":
+		runDebugService(serviceName)
+		return true
+	default:
+		return false
+	}
+
+	if err != nil {
+		logger.Error(eventIDSvcControlError, fmt.Sprintf("%s %s failed: %v", cmd, serviceName, err))
+		os.Exit(1)
+	}
+	return true
+}
+
+// installService registers the current executable with the SCM and sets up
+// an event log source so debug.Log/eventlog writes succeed once installed.
+func installService(name, displayName, description string) error {
+	exePath, err := exePath()
+	if err != nil {
+		return err
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err == nil {
+		s.Close()
+		return fmt.Errorf("service %s already exists", name)
+	}
+
+	s, err = m.CreateService(name, exePath, mgr.Config{
+		StartType:   mgr.StartAutomatic,
+		DisplayName: displayName,
+		Description: description,
+	})
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if err = eventlog.InstallAsEventCreate(name, eventlog.Error|eventlog.Warning|eventlog.Info); err != nil {
+		s.Delete()
+		return fmt.Errorf("could not install event log source: %v", err)
+	}
+
+	return nil
+}
+
+// removeService reverses installService, deleting both the SCM registration
+// and the event log source.
+func removeService(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed", name)
+	}
+	defer s.Close()
+
+	if err = s.Delete(); err != nil {
+		return err
+	}
+
+	if err = eventlog.Remove(name); err != nil {
+		return fmt.Errorf("removed service but failed to remove event log source: %v", err)
+	}
+	return nil
+}
+
+// controlService sends a single SCM control (Pause, Continue, ...) to name
+// and waits for it to reach the expected state.
+func controlService(name string, c svc.Cmd, to svc.State) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("could not access service: %v", err)
+	}
+	defer s.Close()
+
+	if _, err = s.Control(c); err != nil {
+		return fmt.Errorf("could not send control request: %v", err)
+	}
+
+	return waitForState(s, to, defaultWaitTimeout)
+}
+
+// runDebugService runs Execute under debug.Run so operators can iterate on
+// the handler from a console without installing it as a real service.
+func runDebugService(name string) {
+	err := debug.Run(name, &myService{})
+	if err != nil {
+		logger.Error(eventIDSvcControlError, fmt.Sprintf("%s service failed in debug mode: %v", name, err))
+		os.Exit(1)
+	}
+}
+
+// exePath resolves the absolute path to the running executable, adding the
+// .exe suffix when os.Args[0] was given without one. The SCM requires an
+// absolute, fully qualified binary path when registering a service.
+func exePath() (string, error) {
+	prog := os.Args[0]
+	p, err := filepath.Abs(prog)
+	if err != nil {
+		return "", err
+	}
+
+	fi, err := os.Stat(p)
+	if err == nil {
+		if !fi.Mode().IsDir() {
+			return p, nil
+		}
+		err = fmt.Errorf("%s is directory", p)
+	}
+
+	if filepath.Ext(p) == "" {
+		p += ".exe"
+		fi, err = os.Stat(p)
+		if err == nil {
+			if !fi.Mode().IsDir() {
+				return p, nil
+			}
+			err = fmt.Errorf("%s is directory", p)
+		}
+	}
+
+	return "", err
+}
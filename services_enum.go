@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// serviceSummary is what GET /services returns per enumerated service, the
+// discovery surface operators need before they know a service's exact name
+// to pass to /start, /stop or /status.
+type serviceSummary struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	State       string `json:"state"`
+	StartType   uint32 `json:"start_type"`
+	Pid         uint32 `json:"pid"`
+}
+
+// enumerateServices wraps windows.EnumServicesStatusEx, which mgr.Mgr has no
+// exported helper for beyond ListServices (names only). It grows buf until
+// ERROR_MORE_DATA stops firing, the documented pattern for this API.
+func enumerateServices(m *mgr.Mgr, serviceType, serviceState uint32) ([]serviceSummary, error) {
+	var bytesNeeded, servicesReturned, resumeHandle uint32
+	var buf []byte
+
+	for {
+		var bufPtr *byte
+		if len(buf) > 0 {
+			bufPtr = &buf[0]
+		}
+
+		err := windows.EnumServicesStatusEx(
+			m.Handle, windows.SC_ENUM_PROCESS_INFO, serviceType, serviceState,
+			bufPtr, uint32(len(buf)), &bytesNeeded, &servicesReturned, &resumeHandle, nil,
+		)
+		if err == nil {
+			break
+		}
+		if err == windows.ERROR_MORE_DATA {
+			buf = make([]byte, bytesNeeded)
+			continue
+		}
+		return nil, fmt.Errorf("EnumServicesStatusEx failed: %v", err)
+	}
+
+	entrySize := unsafe.Sizeof(windows.ENUM_SERVICE_STATUS_PROCESS{})
+	out := make([]serviceSummary, 0, servicesReturned)
+	for i := uint32(0); i < servicesReturned; i++ {
+		entry := (*windows.ENUM_SERVICE_STATUS_PROCESS)(unsafe.Pointer(&buf[uintptr(i)*entrySize]))
+		name := windows.UTF16PtrToString(entry.ServiceName)
+
+		summary := serviceSummary{
+			Name:        name,
+			DisplayName: windows.UTF16PtrToString(entry.DisplayName),
+			State:       stateName(svc.State(entry.ServiceStatusProcess.CurrentState)),
+			Pid:         entry.ServiceStatusProcess.ProcessId,
+		}
+
+		if s, err := m.OpenService(name); err == nil {
+			if cfg, err := s.Config(); err == nil {
+				summary.StartType = cfg.StartType
+			}
+			s.Close()
+		}
+
+		out = append(out, summary)
+	}
+
+	return out, nil
+}
+
+// parseServiceStateFilter maps ?state= onto the SERVICE_ACTIVE/INACTIVE/
+// STATE_ALL flags EnumServicesStatusEx expects, defaulting to "all".
+func parseServiceStateFilter(v string) uint32 {
+	switch v {
+	case "active":
+		return windows.SERVICE_ACTIVE
+	case "inactive":
+		return windows.SERVICE_INACTIVE
+	default:
+		return windows.SERVICE_STATE_ALL
+	}
+}
+
+// parseServiceTypeFilter maps ?type= onto the SERVICE_WIN32/DRIVER flags,
+// defaulting to both.
+func parseServiceTypeFilter(v string) uint32 {
+	switch v {
+	case "win32":
+		return windows.SERVICE_WIN32
+	case "driver":
+		return windows.SERVICE_DRIVER
+	default:
+		return windows.SERVICE_WIN32 | windows.SERVICE_DRIVER
+	}
+}
+
+func handleServices(w http.ResponseWriter, r *http.Request) {
+	serviceType := parseServiceTypeFilter(r.URL.Query().Get("type"))
+	serviceState := parseServiceStateFilter(r.URL.Query().Get("state"))
+	prefix := r.URL.Query().Get("prefix")
+
+	m, err := mgr.Connect()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not connect to service manager: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer m.Disconnect()
+
+	services, err := enumerateServices(m, serviceType, serviceState)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if prefix != "" {
+		filtered := services[:0]
+		for _, s := range services {
+			if strings.HasPrefix(s.Name, prefix) {
+				filtered = append(filtered, s)
+			}
+		}
+		services = filtered
+	}
+
+	writeJSON(w, http.StatusOK, services)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+)
+
+// Session notification event types carried in ChangeRequest.EventType for a
+// SessionChange control, mirroring WTS_SESSION_* from wtsapi32.h.
+const (
+	wtsConsoleConnect       = 0x1
+	wtsConsoleDisconnect    = 0x2
+	wtsRemoteConnect        = 0x3
+	wtsRemoteDisconnect     = 0x4
+	wtsSessionLogon         = 0x5
+	wtsSessionLogoff        = 0x6
+	wtsSessionLock          = 0x7
+	wtsSessionUnlock        = 0x8
+	wtsSessionRemoteControl = 0x9
+)
+
+// logSessionChange records a SessionChange control request to the log so
+// admins can correlate service behavior with logon/logoff activity.
+func logSessionChange(c svc.ChangeRequest) {
+	if c.EventData == 0 {
+		logger.Warning(eventIDSessionChange, fmt.Sprintf("session change event %d with no event data", c.EventType))
+		return
+	}
+
+	// c.EventData is typed uintptr by the svc package, but for a
+	// SessionChange control it is documented to be the address of a
+	// WTSSESSION_NOTIFICATION, and the SCM guarantees that address stays
+	// valid for the duration of this Execute callback. go vet's unsafeptr
+	// check flags this conversion because it can't prove a bare
+	// syscall-supplied uintptr was ever derived from a Go pointer - that's
+	// expected and accepted here, not a bug to engineer around.
+	//nolint:govet // unsafeptr: EventData's lifetime is guaranteed by the SCM for this callback
+	note := (*windows.WTSSESSION_NOTIFICATION)(unsafe.Pointer(c.EventData))
+	logger.Info(eventIDSessionChange, fmt.Sprintf("session change: %s (session %d)", sessionChangeEventName(c.EventType), note.SessionID))
+}
+
+func sessionChangeEventName(eventType uint32) string {
+	switch eventType {
+	case wtsConsoleConnect:
+		return "console connect"
+	case wtsConsoleDisconnect:
+		return "console disconnect"
+	case wtsRemoteConnect:
+		return "remote connect"
+	case wtsRemoteDisconnect:
+		return "remote disconnect"
+	case wtsSessionLogon:
+		return "session logon"
+	case wtsSessionLogoff:
+		return "session logoff"
+	case wtsSessionLock:
+		return "session lock"
+	case wtsSessionUnlock:
+		return "session unlock"
+	case wtsSessionRemoteControl:
+		return "session remote control"
+	default:
+		return "unknown session event"
+	}
+}